@@ -5,6 +5,7 @@ import "fmt"
 type AssemblerConfig struct {
 	verbose				bool
 	optimizationLevel 	int
+	wideMode			bool
 
 	// TODO: Remove the comment wehen different versions/arch of the VM is made
 	// targetArch 			string
@@ -13,6 +14,11 @@ type AssemblerConfig struct {
 type Assembler struct {
 	symbolTable *SymbolTable
 	config 		AssemblerConfig
+
+	// lastGlobalLabel is the most recently defined non-local label,
+	// tracked while walking lines in pass 1 so a `.local` label right
+	// after it mangles into the correct scope.
+	lastGlobalLabel string
 }
 
 type AssemblerOption func(*AssemblerConfig)
@@ -29,6 +35,15 @@ func WithOptimization(level int) AssemblerOption {
     }
 }
 
+// WithWideMode opts into the wide (32-bit) instruction format: MOVI and
+// jump/call instructions whose resolved immediate doesn't fit in 9 bits
+// are automatically upgraded to it instead of truncating.
+func WithWideMode(enabled bool) AssemblerOption {
+    return func(c *AssemblerConfig) {
+        c.wideMode = enabled
+    }
+}
+
 func NewAssembler(opts ...AssemblerOption) *Assembler {
 	config := AssemblerConfig{
         verbose:         false,
@@ -66,6 +81,16 @@ func (a *Assembler) Assemble(source string) ([]Instruction, error) {
         return nil, fmt.Errorf("pass 2 error: %w", err)
     }
 
+    // Optimize: peephole rewrites and dead-code elimination
+    if a.config.optimizationLevel >= 1 {
+        optimizer := NewOptimizer(a.symbolTable, a.config.verbose)
+        optimized, warnings := optimizer.Optimize(instructions, a.config.optimizationLevel)
+        instructions = optimized
+        for _, w := range warnings {
+            fmt.Printf("Warning: %s\n", w.Error())
+        }
+    }
+
     // Warn about unused labels
     if unused := a.symbolTable.UnusedLabels(); len(unused) > 0 {
         fmt.Printf("Warning: unused labels: %v\n", unused)
@@ -74,28 +99,123 @@ func (a *Assembler) Assemble(source string) ([]Instruction, error) {
     return instructions, nil
 }
 
-// buildSymbolTable is Pass 1: collect all label definitions
+// maxWideRelaxPasses bounds the fixed-point loop buildSymbolTable runs in
+// wide mode: deciding whether an instruction needs the wide form depends
+// on label addresses, which depend on instruction sizes, which depend on
+// which instructions are wide. A handful of passes is enough for this
+// ISA since instructions only ever grow from 2 to 4 bytes, never shrink.
+const maxWideRelaxPasses = 8
+
+// buildSymbolTable is Pass 1: collect all label definitions and compute
+// their addresses. In wide mode this re-runs as a small relaxation loop,
+// widening MOVI/jump instructions whose resolved operand doesn't fit in
+// 9 bits and recomputing addresses until nothing changes.
 func (a *Assembler) buildSymbolTable(lines []Line) error {
-    address := uint32(0)
+    for pass := 0; pass < maxWideRelaxPasses; pass++ {
+        a.symbolTable = NewSymbolTable()
+        a.lastGlobalLabel = ""
+        address := uint32(0)
+        scopeAtLine := make([]string, len(lines))
+
+        for i, line := range lines {
+            scopeAtLine[i] = a.lastGlobalLabel
+            if len(line.Tokens) == 0 {
+                continue
+            }
+
+            // Check if this is a label definition
+            if len(line.Tokens) == 1 && line.Tokens[0].Type == TokenLabel {
+                label := line.Tokens[0].Value
+                if IsLocalLabel(label) {
+                    if err := a.symbolTable.DefineLocal(a.lastGlobalLabel, label, address, line.Number); err != nil {
+                        return fmt.Errorf("line %d: %w", line.Number, err)
+                    }
+                } else {
+                    if err := a.symbolTable.Define(label, address, line.Number); err != nil {
+                        return fmt.Errorf("line %d: %w", line.Number, err)
+                    }
+                    a.lastGlobalLabel = label
+                }
+                // Labels don't consume space
+                continue
+            }
 
-    for _, line := range lines {
-        if len(line.Tokens) == 0 {
-            continue
+            if lines[i].Wide {
+                address += 4
+            } else {
+                address += 2
+            }
         }
 
-        // Check if this is a label definition
-        if len(line.Tokens) == 1 && line.Tokens[0].Type == TokenLabel {
-            label := line.Tokens[0].Value
-            if err := a.symbolTable.Define(label, address, line.Number); err != nil {
-                return fmt.Errorf("line %d: %w", line.Number, err)
+        if !a.config.wideMode {
+            return nil
+        }
+
+        widened := false
+        for i, line := range lines {
+            if lines[i].Wide || len(line.Tokens) == 0 {
+                continue
+            }
+            if len(line.Tokens) == 1 && line.Tokens[0].Type == TokenLabel {
+                continue
+            }
+            if a.needsWideForm(line, scopeAtLine[i]) {
+                lines[i].Wide = true
+                widened = true
             }
-            // Labels don't consume space
-            continue
         }
 
-        // Regular instruction - takes 2 bytes (16 bits)
-        address += 2
+        if !widened {
+            return nil
+        }
     }
 
     return nil
 }
+
+// needsWideForm reports whether a MOVI/jump-family instruction's operand
+// won't fit in the regular format's 9-bit immediate field. scope is the
+// last global label in effect at line, used to mangle a local (`.foo`)
+// operand the same way Parser.resolveLabels does before looking it up.
+func (a *Assembler) needsWideForm(line Line, scope string) bool {
+    tokens := line.Tokens
+    if len(tokens) < 2 {
+        return false
+    }
+
+    opToken := tokens[len(tokens)-1]
+    if opToken.Type != TokenOpcode {
+        return false
+    }
+    if _, ok := WideOpcodeMap[OpcodeMap[opToken.Value]]; !ok {
+        return false
+    }
+
+    // The operand carrying the value we care about always sits right
+    // before the trailing opcode.
+    operand := tokens[len(tokens)-2]
+
+    var value uint32
+    switch operand.Type {
+    case TokenNumber:
+        v, err := parseNumber(operand.Value)
+        if err != nil {
+            return false
+        }
+        value = uint32(v)
+    case TokenLabel:
+        name := operand.Value
+        if IsLocalLabel(name) {
+            name = MangleLocal(scope, name)
+        }
+        sym, ok := a.symbolTable.Get(name)
+        if !ok {
+            return false
+        }
+        value = sym.Address
+    default:
+        return false
+    }
+
+    return value > 0x1FF
+}
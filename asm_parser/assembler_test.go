@@ -0,0 +1,35 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestWideModeRelaxationLoop checks that buildSymbolTable's fixed-point
+// loop converges: a forward JMP whose target only exceeds the 9-bit
+// immediate range once earlier instructions are counted must end up
+// widened, with its resolved address reflecting the widened layout
+// rather than the pre-relaxation one.
+func TestWideModeRelaxationLoop(t *testing.T) {
+	const nops = 300 // 300*2 = 600 bytes, past the 0x1FF (511) narrow limit
+	src := "end TELEPORT\n" + strings.Repeat("IDLE\n", nops) + "end:\n" // TELEPORT = JMP, IDLE = NOP
+
+	asm := NewAssembler(WithWideMode(true))
+	instructions, err := asm.Assemble(src)
+	if err != nil {
+		t.Fatalf("Assemble: %v", err)
+	}
+	if len(instructions) == 0 {
+		t.Fatal("got no instructions")
+	}
+
+	jmp := instructions[0]
+	if !jmp.IsWide {
+		t.Fatalf("JMP = %+v, want IsWide=true", jmp)
+	}
+
+	wantAddr := uint16(4 + nops*2) // JMP itself widened to 4 bytes, then nops*2-byte NOPs
+	if jmp.Immediate != wantAddr {
+		t.Errorf("JMP target = %d, want %d", jmp.Immediate, wantAddr)
+	}
+}
@@ -41,14 +41,39 @@ func (e *Encoder) encodeExtended(instr Instruction) uint16{
 		(uint16(instr.Dst) << 6) |
 		(uint16(instr.Src) << 3)
 }
+
+// Wide instruction (4 bytes): word 1 is OP_WIDE(4) | WIDE_OP(4) | DST(4) |
+// UNUSED(4), word 2 is the full 16-bit immediate.
+func (e *Encoder) encodeWide(instr Instruction) []byte {
+	ctrl := (uint16(OP_WIDE) << 12) |
+		(uint16(instr.WideOpcode) << 8) |
+		(uint16(instr.Dst) << 4)
+
+	return []byte{
+		byte(ctrl & 0xFF), byte((ctrl >> 8) & 0xFF),
+		byte(instr.Immediate & 0xFF), byte((instr.Immediate >> 8) & 0xFF),
+	}
+}
+
+// encodeOne returns an instruction's bytes, 2 for the regular format or 4
+// for the wide one.
+func (e *Encoder) encodeOne(instr Instruction) []byte {
+	if instr.IsWide {
+		return e.encodeWide(instr)
+	}
+
+	encoded := e.Encode(instr)
+	return []byte{byte(encoded & 0xFF), byte((encoded >> 8) & 0xFF)}
+}
+
+// EncodeAll encodes every instruction, little-endian, emitting 2 or 4
+// bytes per instruction depending on whether it was upgraded to the wide
+// form.
 func (e *Encoder) EncodeAll(instructions []Instruction) []byte{
-	binary := make([]byte, len(instructions)*2)
+	binary := make([]byte, 0, len(instructions)*2)
 
-	for i, instr := range instructions {
-		encoded := e.Encode(instr)
-		// Little endian
-		binary[i*2] = byte(encoded & 0xFF)
-		binary[i*2+1] = byte((encoded >> 8) & 0xFF)
+	for _, instr := range instructions {
+		binary = append(binary, e.encodeOne(instr)...)
 	}
 
 	return binary
@@ -6,13 +6,16 @@ type AssemblyStage string
 
 const (
 	StageLexer    AssemblyStage = "lexer"
-    StageParser   AssemblyStage = "parser" 
+    StageParser   AssemblyStage = "parser"
     StageSymbols  AssemblyStage = "symbols"
     StageEncoder  AssemblyStage = "encoder"
+    StagePreproc  AssemblyStage = "preproc"
+    StageOptimizer AssemblyStage = "optimizer"
 )
 
 type AssemblerError struct {
 	Stage 	AssemblyStage
+	File	string
 	Line	int
 	Column  int
 	Token 	string
@@ -21,10 +24,14 @@ type AssemblerError struct {
 }
 
 func (e *AssemblerError) Error() string {
+	loc := fmt.Sprintf("%d", e.Line)
+	if e.File != "" {
+        loc = fmt.Sprintf("%s:%d", e.File, e.Line)
+    }
 	if e.Column > 0 {
-        return fmt.Sprintf("[%s:%d:%d] %s: %s", e.Stage, e.Line, e.Column, e.Token, e.Message)
+        return fmt.Sprintf("[%s:%s:%d] %s: %s", e.Stage, loc, e.Column, e.Token, e.Message)
     }
-    return fmt.Sprintf("[%s:%d] %s", e.Stage, e.Line, e.Message)
+    return fmt.Sprintf("[%s:%s] %s", e.Stage, loc, e.Message)
 }
 
 func (e *AssemblerError) Unwrap() error {
@@ -48,3 +55,26 @@ func NewParserError(line int, msg string) *AssemblerError {
         Message: msg,
     }
 }
+
+// NewPreprocError reports a preprocessor failure (.include/.equ/.macro/
+// .ifdef handling), preserving the file and line of the offending token
+// even when it came from an included file.
+func NewPreprocError(file string, line int, token, msg string) *AssemblerError {
+    return &AssemblerError{
+        Stage:   StagePreproc,
+        File:    file,
+        Line:    line,
+        Token:   token,
+        Message: msg,
+    }
+}
+
+// NewOptimizerWarning reports a non-fatal observation from the optimizer,
+// e.g. a jump whose target was eliminated and had to be redirected.
+func NewOptimizerWarning(line int, msg string) *AssemblerError {
+    return &AssemblerError{
+        Stage:   StageOptimizer,
+        Line:    line,
+        Message: msg,
+    }
+}
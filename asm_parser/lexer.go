@@ -7,13 +7,52 @@ import (
 type Lexer struct {
 	lines []string
 	internPool map[string]string // Reuse common strings
+
+	opener Opener
+	file   string // name of the root source, used to tag preproc errors
+	equs   map[string]string
+	macros map[string]*macroDef
+
+	// macroCallCount is bumped on every macro expansion so expandMacro
+	// can give each call's local labels a unique suffix.
+	macroCallCount int
+}
+
+// LexerOption configures optional Lexer behavior, mirroring AssemblerOption.
+type LexerOption func(*Lexer)
+
+// WithOpener overrides how .include targets are read. Defaults to the
+// filesystem; tests can supply an in-memory Opener instead.
+func WithOpener(o Opener) LexerOption {
+    return func(l *Lexer) {
+        l.opener = o
+    }
+}
+
+// WithSourceName tags the root source with a file name, used in
+// StagePreproc errors and Line.File for the root source's own lines.
+// Lines spliced in via .include carry the include path instead.
+func WithSourceName(name string) LexerOption {
+    return func(l *Lexer) {
+        l.file = name
+    }
 }
 
-func NewLexer(src string) *Lexer {
-	return &Lexer{
+func NewLexer(src string, opts ...LexerOption) *Lexer {
+	l := &Lexer{
 		lines: strings.Split(src, "\n"),
 		internPool: make(map[string]string, 64),
+		opener: FileOpener{},
+		file:   "<source>",
+		equs:   make(map[string]string),
+		macros: make(map[string]*macroDef),
+	}
+
+	for _, opt := range opts {
+		opt(l)
 	}
+
+	return l
 }
 
 func (l *Lexer) intern(s string) string {
@@ -27,10 +66,15 @@ func (l *Lexer) intern(s string) string {
 // I honestly hate K&R style braces, why tf Go don't allow Allaman Style?!
 func (l *Lexer) Tokenize() ([]Line, error) {
 
-	result := make([]Line, 0, len(l.lines))
+	expanded, err := l.preprocess()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Line, 0, len(expanded))
 
-	for lineNum, rawLine := range l.lines {
-		line := l.tokenizeLine(rawLine, lineNum+1) 
+	for _, pl := range expanded {
+		line := l.tokenizeLine(pl.text, pl.file, pl.line)
 		if line != nil {
 			result = append(result, *line)
 		}
@@ -39,7 +83,7 @@ func (l *Lexer) Tokenize() ([]Line, error) {
 	return result, nil
 }
 
-func (l *Lexer) tokenizeLine(rawLine string, lineNo int) *Line {
+func (l *Lexer) tokenizeLine(rawLine string, file string, lineNo int) *Line {
 	original := rawLine
 	line := strings.TrimSpace(rawLine)
 
@@ -71,6 +115,7 @@ func (l *Lexer) tokenizeLine(rawLine string, lineNo int) *Line {
 	parsedLine := &Line {
 		Original: original,
 		Number: lineNo,
+		File: file,
 		Tokens: make([]Token, 0, len(fields)),
 	}
 
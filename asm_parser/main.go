@@ -1,18 +1,24 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 )
 
 func main() {
-	if len(os.Args) != 3 {
-		fmt.Fprintf(os.Stderr, "Usage: %s <input.vm> <output.bin>\n", os.Args[0])
+	optimize := flag.Int("O", 0, "optimization level (0 disables peephole/dead-code passes, 2 also drops unreferenced blocks)")
+	wide := flag.Bool("wide", false, "upgrade MOVI/jump/call instructions to the wide form instead of truncating operands that don't fit in 9 bits")
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) != 2 {
+		fmt.Fprintf(os.Stderr, "Usage: %s [-O level] [-wide] <input.vm> <output.bin>\n", os.Args[0])
 		os.Exit(1)
 	}
 
-	inputFile 	:= os.Args[1]
-	outputFile 	:= os.Args[2]
+	inputFile 	:= args[0]
+	outputFile 	:= args[1]
 
 	source, err := os.ReadFile(inputFile)
 	if err != nil {
@@ -20,45 +26,21 @@ func main() {
 		os.Exit(1)
 	}
 
-	byteCode := []byte("cbin")
-	temp, err := MainAssembly(string(source))
-	
-	for _, b := range temp {
-		byteCode = append(byteCode, b)
-	}
-
+	assembler := NewAssembler(WithOptimization(*optimize), WithWideMode(*wide))
+	instructions, err := assembler.Assemble(string(source))
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
+	encoder := NewEncoder()
+	bytecode := encoder.EncodeAll(instructions)
+
 	writer := NewWriter()
-	if err := writer.WriteBinary(outputFile, byteCode); err != nil {
+	if err := writer.WriteBinary(outputFile, instructions, bytecode, assembler.symbolTable); err != nil {
 		fmt.Fprintf(os.Stderr, "Error writing: %v\n", err)
 		os.Exit(1)
 	}
 
-	fmt.Printf("Success: %d bytes written to %s\n", len(byteCode), outputFile)
-}
-
-func MainAssembly(source string) ([]byte, error) {
-	// Tokenizing
-	lexer := NewLexer(source)
-	lines, err := lexer.Tokenize()
-	if err != nil {
-		return nil, err
-	}
-
-	// Parsing
-	parser := NewParser()
-	instructions, err := parser.Parse(lines)
-	if err != nil {
-		return nil, err
-	}
-
-	// Encode
-	encoder := NewEncoder()
-	bytecode := encoder.EncodeAll(instructions)
-
-	return bytecode, nil
+	fmt.Printf("Success: %d bytes of code written to %s\n", len(bytecode), outputFile)
 }
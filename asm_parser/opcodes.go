@@ -18,6 +18,7 @@ const (
 	OP_STDOUT Opcode = 0xB
 	OP_STDIN  Opcode = 0xC
 	OP_EXT    Opcode = 0xD
+	OP_WIDE   Opcode = 0xE
 )
 
 // ExtOpcode represents extended opcodes (when OP_EXT is used)
@@ -34,20 +35,57 @@ const (
 	EXT_XOR   ExtOpcode = 0x7
 )
 
+// WideOpcode represents a wide-form opcode (when OP_WIDE is used), for
+// instructions that need a full 16-bit immediate instead of the regular
+// format's 9 bits. See Encoder.encodeWide.
+type WideOpcode uint8
+
+const (
+	WIDE_MOVI WideOpcode = 0x0
+	WIDE_JMP  WideOpcode = 0x1
+	WIDE_JZ   WideOpcode = 0x2
+	WIDE_JNZ  WideOpcode = 0x3
+	WIDE_CALL WideOpcode = 0x4
+)
+
+// WideOpcodeMap maps the regular opcode each wide form upgrades from, so
+// the resolver pass can swap one for the other once it knows an
+// immediate won't fit in 9 bits.
+var WideOpcodeMap = map[Opcode]WideOpcode{
+	OP_MOVI: WIDE_MOVI,
+	OP_JMP:  WIDE_JMP,
+	OP_JZ:   WIDE_JZ,
+	OP_JNZ:  WIDE_JNZ,
+	OP_CALL: WIDE_CALL,
+}
+
 // Register represents a VM register
 type Register uint8
 
 const (
-	R0 Register = 0
-	R1 Register = 1
-	R2 Register = 2
-	R3 Register = 3
-	R4 Register = 4
-	R5 Register = 5
-	R6 Register = 6
-	R7 Register = 7
+	R0  Register = 0
+	R1  Register = 1
+	R2  Register = 2
+	R3  Register = 3
+	R4  Register = 4
+	R5  Register = 5
+	R6  Register = 6
+	R7  Register = 7
+	R8  Register = 8
+	R9  Register = 9
+	R10 Register = 10
+	R11 Register = 11
+	R12 Register = 12
+	R13 Register = 13
+	R14 Register = 14
+	R15 Register = 15
 )
 
+// MaxNarrowRegister is the highest register index the regular (2-byte)
+// instruction format can address with its 3-bit register fields. R8..R15
+// only exist for the wide format.
+const MaxNarrowRegister = R7
+
 // OpcodeMap maps assembly mnemonics to opcodes
 var OpcodeMap = map[string]Opcode{
 	// Full names
@@ -100,14 +138,22 @@ var ExtOpcodeMap = map[string]ExtOpcode{
 
 // RegisterMap maps register names to register numbers
 var RegisterMap = map[string]Register{
-	"R0": R0,
-	"R1": R1,
-	"R2": R2,
-	"R3": R3,
-	"R4": R4,
-	"R5": R5,
-	"R6": R6,
-	"R7": R7,
+	"R0":  R0,
+	"R1":  R1,
+	"R2":  R2,
+	"R3":  R3,
+	"R4":  R4,
+	"R5":  R5,
+	"R6":  R6,
+	"R7":  R7,
+	"R8":  R8,
+	"R9":  R9,
+	"R10": R10,
+	"R11": R11,
+	"R12": R12,
+	"R13": R13,
+	"R14": R14,
+	"R15": R15,
 }
 
 // InstructionType defines how many operands an instruction needs
@@ -143,6 +189,7 @@ var OpcodeTable = map[Opcode]OpcodeInfo{
 	OP_STDOUT: {TypeOneReg, "STDOUT"},
 	OP_STDIN:  {TypeOneReg, "STDIN"},
 	OP_EXT:    {TypeExtended, "EXT"},
+	OP_WIDE:   {TypeExtended, "WIDE"},
 }
 
 // ExtOpcodeInfo holds metadata about extended opcodes
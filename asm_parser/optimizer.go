@@ -0,0 +1,354 @@
+package main
+
+import "fmt"
+
+// Optimizer performs peephole and dead-code elimination passes over a
+// parsed instruction stream, driven by AssemblerConfig.optimizationLevel.
+//
+// NOTE: by the time Optimize runs, Parser has already baked resolved
+// label addresses directly into jump/call immediates (see
+// Parser.resolveLabels), and Assembler.buildSymbolTable has already
+// baked addresses into the symbol table. Dropping or folding
+// instructions shifts every later instruction's address, so relocate
+// re-bases both of those against the post-removal layout before
+// returning - see its doc comment for how it identifies what to rewrite.
+type Optimizer struct {
+	symbolTable *SymbolTable
+	verbose     bool
+}
+
+// NewOptimizer creates an Optimizer bound to the symbol table produced
+// during assembly, so dead-code elimination can consult Symbol.Used.
+func NewOptimizer(symbolTable *SymbolTable, verbose bool) *Optimizer {
+	return &Optimizer{
+		symbolTable: symbolTable,
+		verbose:     verbose,
+	}
+}
+
+// Optimize rewrites the instruction stream according to level:
+//
+//	1: fold `MOVI Rx, 0` + `ADD Rx, Ry` into `MOV Rx, Ry`, drop NOPs,
+//	   collapse a JMP to the very next instruction, and trim dead code
+//	   following HALT/RET up to the next referenced label.
+//	2: additionally drops whole blocks whose leading label is unused.
+//
+// Level 0 returns the instructions unchanged.
+func (o *Optimizer) Optimize(instructions []Instruction, level int) ([]Instruction, []*AssemblerError) {
+	if level < 1 {
+		return instructions, nil
+	}
+
+	addrs := instructionAddresses(instructions)
+	var warnings []*AssemblerError
+	var summary []string
+
+	removed := make([]bool, len(instructions))
+	rewritten := make(map[int]Instruction)
+
+	o.foldMoviAdd(instructions, removed, rewritten, &summary)
+	o.dropNops(instructions, removed, &summary)
+	o.collapseFallthroughJumps(instructions, addrs, removed, &summary)
+	o.trimTrailingDeadCode(instructions, addrs, removed, &summary)
+
+	if level >= 2 {
+		o.dropUnreferencedBlocks(instructions, addrs, removed, &summary)
+	}
+
+	o.relocate(instructions, addrs, removed, rewritten, &warnings)
+
+	out := make([]Instruction, 0, len(instructions))
+	for i, instr := range instructions {
+		if removed[i] {
+			continue
+		}
+		if r, ok := rewritten[i]; ok {
+			instr = r
+		}
+		out = append(out, instr)
+	}
+
+	if o.verbose {
+		for _, line := range summary {
+			fmt.Println(line)
+		}
+	}
+
+	return out, warnings
+}
+
+// instructionAddresses returns each instruction's byte address, assuming
+// the sequential 2/4-byte layout Assembler.buildSymbolTable already used
+// to resolve every jump target in the stream being optimized.
+func instructionAddresses(instructions []Instruction) []uint32 {
+	addrs := make([]uint32, len(instructions))
+	address := uint32(0)
+	for i, instr := range instructions {
+		addrs[i] = address
+		address += instrSize(instr)
+	}
+	return addrs
+}
+
+// instrSize is how many bytes an instruction occupies once encoded.
+func instrSize(instr Instruction) uint32 {
+	if instr.IsWide {
+		return 4
+	}
+	return 2
+}
+
+// jumpOpcodes are the instructions whose Immediate, when IsImm is set,
+// is a resolved instruction address rather than an arbitrary value (see
+// Parser.ParseRegular) - only these need their target rewritten by
+// relocate.
+var jumpOpcodes = map[Opcode]bool{
+	OP_JMP:  true,
+	OP_JZ:   true,
+	OP_JNZ:  true,
+	OP_CALL: true,
+}
+
+// relocate re-bases everything that was resolved against the
+// pre-optimization instruction layout - jump/call targets and symbol
+// table addresses - against the layout that remains after removed and
+// rewritten have been applied. Without this, dropping or folding any
+// instruction upstream of a jump silently invalidates every later
+// address, since Parser already baked addresses into both places before
+// Optimize ever saw the stream.
+func (o *Optimizer) relocate(instructions []Instruction, addrs []uint32, removed []bool, rewritten map[int]Instruction, warnings *[]*AssemblerError) {
+	removedAddr := make(map[uint32]bool, len(instructions))
+	for i, a := range addrs {
+		if removed[i] {
+			removedAddr[a] = true
+		}
+	}
+
+	// addrMap maps every pre-optimization address onto the address
+	// execution actually reaches afterward: a surviving instruction maps
+	// to its own new address, and a removed one maps forward to whatever
+	// now occupies its old spot (the next survivor's new address, since
+	// newAddr only advances for survivors below) - so a jump that used
+	// to target eliminated dead code still lands somewhere live instead
+	// of going stale.
+	addrMap := make(map[uint32]uint32, len(instructions)+1)
+	newAddr := uint32(0)
+	for i, instr := range instructions {
+		addrMap[addrs[i]] = newAddr
+		if removed[i] {
+			continue
+		}
+		final := instr
+		if r, ok := rewritten[i]; ok {
+			final = r
+		}
+		newAddr += instrSize(final)
+	}
+	// A label may also sit one-past-the-end of the program (nothing
+	// after it); map that address too so such a symbol still relocates.
+	if len(instructions) > 0 {
+		last := len(instructions) - 1
+		addrMap[addrs[last]+instrSize(instructions[last])] = newAddr
+	}
+
+	for i, instr := range instructions {
+		if removed[i] {
+			continue
+		}
+		final := instr
+		if r, ok := rewritten[i]; ok {
+			final = r
+		}
+		if !final.IsImm || !jumpOpcodes[final.Opcode] {
+			continue
+		}
+
+		target := uint32(final.Immediate)
+		newTarget, ok := addrMap[target]
+		if !ok {
+			continue
+		}
+
+		if removedAddr[target] {
+			*warnings = append(*warnings, NewOptimizerWarning(final.Line,
+				fmt.Sprintf("jump target at address 0x%X was eliminated as dead code; redirected to the next live instruction at 0x%X", target, newTarget)))
+		}
+		if newTarget != target {
+			final.Immediate = uint16(newTarget)
+			rewritten[i] = final
+		}
+	}
+
+	for _, sym := range o.symbolTable.AllSymbols() {
+		if newAddr, ok := addrMap[sym.Address]; ok {
+			sym.Address = newAddr
+		}
+	}
+}
+
+// foldMoviAdd rewrites `MOVI Rx, 0` immediately followed by `ADD Rx, Ry`
+// into a single `MOV Rx, Ry`.
+func (o *Optimizer) foldMoviAdd(instructions []Instruction, removed []bool, rewritten map[int]Instruction, summary *[]string) {
+	for i := 0; i+1 < len(instructions); i++ {
+		if removed[i] || removed[i+1] {
+			continue
+		}
+
+		movi := instructions[i]
+		add := instructions[i+1]
+
+		if movi.Opcode != OP_MOVI || !movi.IsImm || movi.Immediate != 0 {
+			continue
+		}
+		if add.Opcode != OP_EXT || !add.IsExt || add.ExtOpcode != EXT_ADD || add.Dst != movi.Dst {
+			continue
+		}
+
+		rewritten[i] = Instruction{
+			Opcode: OP_MOV,
+			Dst:    movi.Dst,
+			Src:    add.Src,
+			Line:   movi.Line,
+		}
+		removed[i+1] = true
+		*summary = append(*summary, fmt.Sprintf("optimize: line %d-%d: folded MOVI/ADD into MOV", movi.Line, add.Line))
+	}
+}
+
+// dropNops removes every NOP instruction.
+func (o *Optimizer) dropNops(instructions []Instruction, removed []bool, summary *[]string) {
+	for i, instr := range instructions {
+		if removed[i] {
+			continue
+		}
+		if instr.Opcode == OP_NOP {
+			removed[i] = true
+			*summary = append(*summary, fmt.Sprintf("optimize: line %d: dropped NOP", instr.Line))
+		}
+	}
+}
+
+// collapseFallthroughJumps removes unconditional jumps whose target is
+// simply the next instruction.
+func (o *Optimizer) collapseFallthroughJumps(instructions []Instruction, addrs []uint32, removed []bool, summary *[]string) {
+	for i, instr := range instructions {
+		if removed[i] || instr.Opcode != OP_JMP || !instr.IsImm {
+			continue
+		}
+
+		next := i + 1
+		for next < len(instructions) && removed[next] {
+			next++
+		}
+		if next >= len(instructions) {
+			continue
+		}
+
+		if uint32(instr.Immediate) == addrs[next] {
+			removed[i] = true
+			*summary = append(*summary, fmt.Sprintf("optimize: line %d: collapsed jump to next instruction", instr.Line))
+		}
+	}
+}
+
+// trimTrailingDeadCode removes instructions that follow a HALT or RET up
+// until the next address a referenced (Used) label points at - code
+// reachable only by falling off the end of a terminated block is dead,
+// but we stop as soon as something could jump back in.
+func (o *Optimizer) trimTrailingDeadCode(instructions []Instruction, addrs []uint32, removed []bool, summary *[]string) {
+	usedAddrs := o.usedLabelAddresses()
+
+	for i, instr := range instructions {
+		if removed[i] {
+			continue
+		}
+		if !isTerminator(instr) {
+			continue
+		}
+
+		for j := i + 1; j < len(instructions); j++ {
+			if removed[j] {
+				continue
+			}
+			if usedAddrs[addrs[j]] {
+				break
+			}
+			removed[j] = true
+			*summary = append(*summary, fmt.Sprintf("optimize: line %d: removed unreachable instruction after %s", instructions[j].Line, terminatorName(instr)))
+		}
+	}
+}
+
+// dropUnreferencedBlocks (level >= 2) deletes every instruction in a
+// basic block whose leading label is defined but never referenced. A
+// label at address 0 (the program's entry) is never treated this way.
+func (o *Optimizer) dropUnreferencedBlocks(instructions []Instruction, addrs []uint32, removed []bool, summary *[]string) {
+	symbols := o.symbolTable.AllSymbols()
+	if len(symbols) == 0 {
+		return
+	}
+
+	// Sort label addresses so we know where each block ends.
+	sorted := append([]*Symbol(nil), symbols...)
+	for i := 1; i < len(sorted); i++ {
+		for j := i; j > 0 && sorted[j-1].Address > sorted[j].Address; j-- {
+			sorted[j-1], sorted[j] = sorted[j], sorted[j-1]
+		}
+	}
+
+	addrToIndex := make(map[uint32]int, len(instructions))
+	for i, a := range addrs {
+		if _, exists := addrToIndex[a]; !exists {
+			addrToIndex[a] = i
+		}
+	}
+
+	for si, sym := range sorted {
+		if sym.Used || sym.Address == 0 {
+			continue
+		}
+
+		start, ok := addrToIndex[sym.Address]
+		if !ok {
+			continue
+		}
+
+		end := len(instructions)
+		if si+1 < len(sorted) {
+			if e, ok := addrToIndex[sorted[si+1].Address]; ok {
+				end = e
+			}
+		}
+
+		for i := start; i < end; i++ {
+			if !removed[i] {
+				removed[i] = true
+				*summary = append(*summary, fmt.Sprintf("optimize: line %d: removed (unreferenced label %q)", instructions[i].Line, sym.Name))
+			}
+		}
+	}
+}
+
+func (o *Optimizer) usedLabelAddresses() map[uint32]bool {
+	used := make(map[uint32]bool)
+	for _, sym := range o.symbolTable.AllSymbols() {
+		if sym.Used {
+			used[sym.Address] = true
+		}
+	}
+	return used
+}
+
+func isTerminator(instr Instruction) bool {
+	if instr.Opcode == OP_HALT {
+		return true
+	}
+	return instr.Opcode == OP_EXT && instr.IsExt && instr.ExtOpcode == EXT_RET
+}
+
+func terminatorName(instr Instruction) string {
+	if instr.Opcode == OP_HALT {
+		return "HALT"
+	}
+	return "RET"
+}
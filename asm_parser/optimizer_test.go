@@ -0,0 +1,64 @@
+package main
+
+import "testing"
+
+// TestOptimizeRelocatesJumpPastDroppedNOP checks the concrete failure
+// mode relocate exists to fix: a JMP whose resolved target sits after a
+// NOP that dropNops removes must have its immediate
+// rewritten to the target's new address, not left pointing at the
+// pre-optimization layout.
+func TestOptimizeRelocatesJumpPastDroppedNOP(t *testing.T) {
+	// PUT = MOVI, IDLE = NOP, TELEPORT = JMP, DIE = HALT
+	src := "R0 0 PUT\nIDLE\nTARGET TELEPORT\nR0 1 PUT\nTARGET:\nDIE\n"
+
+	asm := NewAssembler(WithOptimization(1))
+	instructions, err := asm.Assemble(src)
+	if err != nil {
+		t.Fatalf("Assemble: %v", err)
+	}
+
+	var jmp *Instruction
+	for i := range instructions {
+		if instructions[i].Opcode == OP_JMP {
+			jmp = &instructions[i]
+		}
+	}
+	if jmp == nil {
+		t.Fatal("JMP instruction was not found in optimized output")
+	}
+
+	// addrs pre-optimization: MOVI@0, NOP@2, JMP@4, MOVI@6, TARGET/HALT@8.
+	// Dropping the NOP shifts HALT (and TARGET) back to address 6.
+	if jmp.Immediate != 6 {
+		t.Errorf("JMP target = %d, want 6 (relocated past the dropped NOP)", jmp.Immediate)
+	}
+}
+
+// TestOptimizeRedirectsJumpIntoEliminatedDeadCode checks that a jump
+// whose target was itself removed (here a dropped NOP) gets redirected
+// to the next surviving instruction (where control actually ends up)
+// rather than left stale. relocate also reports this as a StageOptimizer
+// warning, which Assemble prints; this test does not check that warning.
+func TestOptimizeRedirectsJumpIntoEliminatedDeadCode(t *testing.T) {
+	// L resolves to the NOP's address, not the instruction right after
+	// the JMP, so this isolates dead-code redirection from the separate
+	// collapseFallthroughJumps pass.
+	src := "L TELEPORT\nR0 0 PUT\nL:\nIDLE\nDIE\n"
+
+	asm := NewAssembler(WithOptimization(1))
+	instructions, err := asm.Assemble(src)
+	if err != nil {
+		t.Fatalf("Assemble: %v", err)
+	}
+
+	if len(instructions) != 3 {
+		t.Fatalf("got %d instructions, want 3 (JMP, MOVI, HALT): %+v", len(instructions), instructions)
+	}
+	jmp, halt := instructions[0], instructions[2]
+	if jmp.Opcode != OP_JMP || halt.Opcode != OP_HALT {
+		t.Fatalf("instructions = %+v, want [JMP, MOVI, HALT]", instructions)
+	}
+	if jmp.Immediate != 4 {
+		t.Errorf("JMP target = %d, want 4 (redirected to HALT's new address, past the dropped NOP)", jmp.Immediate)
+	}
+}
@@ -9,6 +9,11 @@ import (
 // Parser converts tokens into instructions
 type Parser struct {
 	symbolTable *SymbolTable
+
+	// lastGlobal is the most recently seen non-local label, tracked
+	// while walking lines so `.local` references mangle into the same
+	// scope Assembler.buildSymbolTable used to define them.
+	lastGlobal string
 }
 
 func NewParser(symbolTable *SymbolTable) *Parser {
@@ -26,8 +31,13 @@ func (p *Parser) Parse(lines []Line) ([]Instruction, error) {
 			continue
 		}
 
-		// Skip label definitions (they were already processed in Pass 1)
+		// Skip label definitions (they were already processed in Pass 1),
+		// but keep tracking scope so local label references below still
+		// mangle to the same name Assembler.buildSymbolTable defined.
 		if len(line.Tokens) == 1 && line.Tokens[0].Type == TokenLabel {
+			if label := line.Tokens[0].Value; !IsLocalLabel(label) {
+				p.lastGlobal = label
+			}
 			continue
 		}
 
@@ -73,8 +83,13 @@ func (p *Parser) resolveLabels(tokens []Token, lineNo int) ([]Token, error) {
 
 	for i, token := range resolved {
 		if token.Type == TokenLabel {
-			// Symbol Table lookup
-			addr, ok := p.symbolTable.Resolve(token.Value)
+			// Symbol Table lookup, mangling local (`.foo`) references
+			// into the scope they were defined under first.
+			name := token.Value
+			if IsLocalLabel(name) {
+				name = MangleLocal(p.lastGlobal, name)
+			}
+			addr, ok := p.symbolTable.Resolve(name)
 			if !ok {
 				return nil, fmt.Errorf("undefined label: %s", token.Value)
 			}
@@ -171,9 +186,34 @@ func (p *Parser) ParseRegular(line Line, tokens []Token) (Instruction, error) {
 		return Instruction{}, fmt.Errorf("unhandled opcode: %s", opToken.Value)
 	}
 
+	// Assembler.buildSymbolTable already decided, during its relaxation
+	// pass, whether this line's immediate needs the wide form.
+	if line.Wide && instr.IsImm {
+		wideOp, ok := WideOpcodeMap[op]
+		if !ok {
+			return Instruction{}, fmt.Errorf("%s has no wide form", opToken.Value)
+		}
+		instr.IsWide = true
+		instr.WideOpcode = wideOp
+	}
+
+	if !instr.IsWide {
+		if reg := widerOf(instr.Dst, instr.Src); reg > MaxNarrowRegister {
+			return Instruction{}, fmt.Errorf("register R%d needs wide mode (regular instructions only address up to R%d)", reg, MaxNarrowRegister)
+		}
+	}
+
 	return instr, nil
 }
 
+// widerOf returns whichever of the two registers has the larger index.
+func widerOf(a, b Register) Register {
+	if a > b {
+		return a
+	}
+	return b
+}
+
 func (p *Parser) ParseExtended(line Line) (Instruction, error) {
 	tokens := line.Tokens
 	extToken := tokens[len(tokens)-1]
@@ -208,6 +248,13 @@ func (p *Parser) ParseExtended(line Line) (Instruction, error) {
 		return Instruction{}, fmt.Errorf("unhandled extended opcode: %s", extToken.Value)
 	}
 
+	// Unlike the regular format, extended instructions have no wide form
+	// yet to upgrade to (see WideOpcodeMap), so R8-R15 can never be
+	// packed into the 3-bit Dst/Src fields without corrupting ExtOpcode.
+	if reg := widerOf(instr.Dst, instr.Src); reg > MaxNarrowRegister {
+		return Instruction{}, fmt.Errorf("register R%d needs wide mode, but extended instructions have no wide form yet (max R%d)", reg, MaxNarrowRegister)
+	}
+
 	return instr, nil
 }
 
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Opener resolves the target of a .include directive to its source text.
+// The default, FileOpener, reads from the filesystem; tests can supply
+// an in-memory implementation instead.
+type Opener interface {
+	Open(path string) (string, error)
+}
+
+// FileOpener reads .include targets straight off disk.
+type FileOpener struct{}
+
+func (FileOpener) Open(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// ppLine is one line of source after macro/include/equ expansion, still
+// carrying the file and line number it actually came from so later
+// errors point at what the user wrote, not the spliced-together stream.
+type ppLine struct {
+	text string
+	file string
+	line int
+}
+
+// macroDef is a `.macro NAME arg...` / `.endm` block as written; its body
+// is substituted textually at each call site.
+type macroDef struct {
+	params []string
+	body   []ppLine
+}
+
+// preprocess expands .include, .equ, .macro/.endm and .ifdef/.ifndef/
+// .else/.endif over the root source, returning the flattened line stream
+// that Tokenize operates on.
+func (l *Lexer) preprocess() ([]ppLine, error) {
+	return l.expandLines(l.lines, l.file, nil)
+}
+
+// expandLines processes one file's worth of raw lines. ifStack is the
+// initial stack of "is this conditional branch active" flags; callers
+// pass nil, so each file (root or included) must balance its own
+// .ifdef/.endif. A .include sitting inside an inactive block is skipped
+// before it is ever opened.
+func (l *Lexer) expandLines(lines []string, file string, ifStack []bool) ([]ppLine, error) {
+	var out []ppLine
+
+	active := func() bool {
+		for _, v := range ifStack {
+			if !v {
+				return false
+			}
+		}
+		return true
+	}
+
+	for i := 0; i < len(lines); i++ {
+		lineNo := i + 1
+		trimmed := strings.TrimSpace(lines[i])
+		fields := strings.Fields(trimmed)
+
+		if len(fields) == 0 {
+			continue
+		}
+
+		directive := strings.ToLower(fields[0])
+
+		switch directive {
+		case ".ifdef", ".ifndef":
+			if len(fields) != 2 {
+				return nil, NewPreprocError(file, lineNo, fields[0], "expected exactly one name")
+			}
+			defined := l.isDefined(fields[1])
+			want := directive == ".ifdef"
+			ifStack = append(ifStack, active() && defined == want)
+			continue
+
+		case ".else":
+			if len(ifStack) == 0 {
+				return nil, NewPreprocError(file, lineNo, fields[0], ".else without matching .ifdef/.ifndef")
+			}
+			parentActive := true
+			for _, v := range ifStack[:len(ifStack)-1] {
+				parentActive = parentActive && v
+			}
+			ifStack[len(ifStack)-1] = parentActive && !ifStack[len(ifStack)-1]
+			continue
+
+		case ".endif":
+			if len(ifStack) == 0 {
+				return nil, NewPreprocError(file, lineNo, fields[0], ".endif without matching .ifdef/.ifndef")
+			}
+			ifStack = ifStack[:len(ifStack)-1]
+			continue
+		}
+
+		if !active() {
+			continue
+		}
+
+		switch directive {
+		case ".include":
+			path, err := parseIncludeTarget(trimmed)
+			if err != nil {
+				return nil, NewPreprocError(file, lineNo, fields[0], err.Error())
+			}
+			src, err := l.opener.Open(path)
+			if err != nil {
+				return nil, NewPreprocError(file, lineNo, fields[0], fmt.Sprintf("opening include %q: %v", path, err))
+			}
+			included, err := l.expandLines(strings.Split(src, "\n"), path, nil)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, included...)
+
+		case ".equ":
+			if len(fields) != 3 {
+				return nil, NewPreprocError(file, lineNo, fields[0], "expected .equ NAME value")
+			}
+			l.equs[strings.ToUpper(fields[1])] = fields[2]
+
+		case ".macro":
+			if len(fields) < 2 {
+				return nil, NewPreprocError(file, lineNo, fields[0], "expected .macro NAME [args...]")
+			}
+			name := strings.ToUpper(fields[1])
+			def := &macroDef{params: fields[2:]}
+
+			end := -1
+			for j := i + 1; j < len(lines); j++ {
+				if strings.ToLower(strings.TrimSpace(lines[j])) == ".endm" {
+					end = j
+					break
+				}
+				def.body = append(def.body, ppLine{text: lines[j], file: file, line: j + 1})
+			}
+			if end == -1 {
+				return nil, NewPreprocError(file, lineNo, fields[0], "missing matching .endm")
+			}
+			l.macros[name] = def
+			i = end
+
+		default:
+			if macro, ok := l.macros[strings.ToUpper(fields[0])]; ok {
+				expanded, err := l.expandMacro(macro, fields[1:], file, lineNo)
+				if err != nil {
+					return nil, err
+				}
+				out = append(out, expanded...)
+				continue
+			}
+
+			out = append(out, ppLine{text: l.substituteEqus(lines[i]), file: file, line: lineNo})
+		}
+	}
+
+	if len(ifStack) != 0 {
+		return nil, NewPreprocError(file, len(lines), ".ifdef", "unterminated conditional block (missing .endif)")
+	}
+
+	return out, nil
+}
+
+func (l *Lexer) isDefined(name string) bool {
+	name = strings.ToUpper(name)
+	if _, ok := l.equs[name]; ok {
+		return true
+	}
+	_, ok := l.macros[name]
+	return ok
+}
+
+// expandMacro substitutes a macro's declared parameters with the call
+// site's arguments, textually, across every line of its body.
+//
+// Substitution order matters: params are replaced longest-name-first so
+// that a param like "val" can't eat the prefix of another param "val2"
+// before "val2" gets its turn (e.g. `.macro FOO val val2` called as
+// `FOO 5 6` must not turn a body reference to `%val2` into `52`).
+func (l *Lexer) expandMacro(def *macroDef, args []string, callFile string, callLine int) ([]ppLine, error) {
+	if len(args) != len(def.params) {
+		return nil, NewPreprocError(callFile, callLine, "", fmt.Sprintf("macro expects %d argument(s), got %d", len(def.params), len(args)))
+	}
+
+	order := make([]int, len(def.params))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool {
+		return len(def.params[order[i]]) > len(def.params[order[j]])
+	})
+
+	callID := l.nextMacroCall()
+
+	expanded := make([]ppLine, 0, len(def.body))
+	for _, bodyLine := range def.body {
+		text := bodyLine.text
+		for _, i := range order {
+			text = strings.ReplaceAll(text, "%"+def.params[i], args[i])
+		}
+		text = mangleMacroLocalLabels(text, callID)
+		expanded = append(expanded, ppLine{text: l.substituteEqus(text), file: callFile, line: callLine})
+	}
+	return expanded, nil
+}
+
+// nextMacroCall returns a counter unique to this expansion, used to keep
+// a macro body's own local labels from colliding across repeated calls.
+func (l *Lexer) nextMacroCall() int {
+	l.macroCallCount++
+	return l.macroCallCount
+}
+
+// localLabelRef matches a local-label token (`.name`), whether it's a
+// definition (`.loop:`, the trailing ':' isn't part of the match) or a
+// reference (`.loop`).
+var localLabelRef = regexp.MustCompile(`\.[A-Za-z_][A-Za-z0-9_]*`)
+
+// mangleMacroLocalLabels appends callID to every local label referenced
+// in a macro body line. Expansion is purely textual, so without this a
+// local label declared inside a macro (e.g. `.loop:`) collides the
+// moment the macro is called twice under the same global scope - this
+// keeps each call's labels distinct while still starting with '.', so
+// IsLocalLabel/MangleLocal treat them exactly as before.
+func mangleMacroLocalLabels(text string, callID int) string {
+	return localLabelRef.ReplaceAllStringFunc(text, func(m string) string {
+		return fmt.Sprintf("%s__%d", m, callID)
+	})
+}
+
+// substituteEqus replaces any whole-word reference to a .equ constant
+// with its value, so it can be used anywhere a number is accepted.
+func (l *Lexer) substituteEqus(text string) string {
+	if len(l.equs) == 0 {
+		return text
+	}
+
+	fields := strings.Fields(strings.ReplaceAll(text, ",", " , "))
+	for i, field := range fields {
+		if val, ok := l.equs[strings.ToUpper(field)]; ok {
+			fields[i] = val
+		}
+	}
+	return strings.ReplaceAll(strings.Join(fields, " "), " , ", ",")
+}
+
+func parseIncludeTarget(line string) (string, error) {
+	start := strings.IndexByte(line, '"')
+	if start == -1 {
+		return "", fmt.Errorf(`expected .include "file"`)
+	}
+	end := strings.IndexByte(line[start+1:], '"')
+	if end == -1 {
+		return "", fmt.Errorf(`unterminated string in .include`)
+	}
+	return line[start+1 : start+1+end], nil
+}
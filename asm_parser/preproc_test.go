@@ -0,0 +1,57 @@
+package main
+
+import "testing"
+
+// TestExpandMacroLocalLabelsDontCollide checks that two calls to the same
+// macro under one global scope get distinct local-label suffixes, so the
+// second call's JZ doesn't silently jump into the first call's loop (see
+// mangleMacroLocalLabels).
+func TestExpandMacroLocalLabelsDontCollide(t *testing.T) {
+	src := ".macro LOOP n\n.loop:\nMOVI R0, %n\nJZ .loop\n.endm\n\nLOOP 1\nLOOP 2\n"
+	l := NewLexer(src)
+
+	lines, err := l.preprocess()
+	if err != nil {
+		t.Fatalf("preprocess: %v", err)
+	}
+
+	var labels []string
+	for _, pl := range lines {
+		if pl.text == ".loop:" || pl.text == "JZ .loop" {
+			t.Fatalf("found unmangled local label in expansion: %q", pl.text)
+		}
+		if pl.text[:1] == "." {
+			labels = append(labels, pl.text)
+		}
+	}
+
+	if len(labels) != 2 {
+		t.Fatalf("got %d local label definitions, want 2: %v", len(labels), labels)
+	}
+	if labels[0] == labels[1] {
+		t.Fatalf("both macro calls produced the same local label %q, want distinct suffixes", labels[0])
+	}
+}
+
+// TestExpandMacroParamSubstitutionLongestFirst checks that a param whose
+// name prefixes another param (val vs val2) doesn't get substituted into
+// the longer param's reference first.
+func TestExpandMacroParamSubstitutionLongestFirst(t *testing.T) {
+	src := ".macro FOO val val2\nMOVI R0, %val\nMOVI R1, %val2\n.endm\n\nFOO 5 6\n"
+	l := NewLexer(src)
+
+	lines, err := l.preprocess()
+	if err != nil {
+		t.Fatalf("preprocess: %v", err)
+	}
+
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2: %v", len(lines), lines)
+	}
+	if lines[0].text != "MOVI R0, 5" {
+		t.Errorf("lines[0].text = %q, want %q", lines[0].text, "MOVI R0, 5")
+	}
+	if lines[1].text != "MOVI R1, 6" {
+		t.Errorf("lines[1].text = %q, want %q", lines[1].text, "MOVI R1, 6")
+	}
+}
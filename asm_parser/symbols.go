@@ -35,6 +35,32 @@ func (st *SymbolTable) Define(label string, address uint32, line int) error {
 	return nil
 }
 
+// IsLocalLabel reports whether name is a local label (6502-assembler
+// style: anything starting with '.'), scoped to the last global label
+// defined before it rather than the whole file.
+func IsLocalLabel(name string) bool {
+	return len(name) > 0 && name[0] == '.'
+}
+
+// MangleLocal builds the internal name a local label is stored and
+// looked up under: the last global label it's scoped to, followed by the
+// local name as written (including its leading '.'). Both Assembler
+// (pass 1) and Parser (pass 2) must mangle the same way to agree on
+// where a `.foo` reference resolves to.
+func MangleLocal(scope, name string) string {
+	return scope + name
+}
+
+// DefineLocal adds a label scoped to the most recent global label. It is
+// stored under its mangled name, so `.loop` under `main` and `.loop`
+// under `other` don't collide. A `.loop` written inside a macro body
+// carries an extra per-call suffix by the time it reaches here (see
+// mangleMacroLocalLabels), so two calls to the same macro under the same
+// scope don't collide with each other either.
+func (st *SymbolTable) DefineLocal(scope, label string, address uint32, line int) error {
+	return st.Define(MangleLocal(scope, label), address, line)
+}
+
 // Resolve looks up a label's address and marks it as used
 func (st *SymbolTable) Resolve(label string) (uint32, bool) {
 	addr, ok := st.symbols[label]
@@ -27,18 +27,22 @@ type Line struct {
 	Tokens   []Token
 	Original string
 	Number   int
+	File     string // source file this line came from, after .include expansion
+	Wide     bool    // set by Assembler.buildSymbolTable when this instruction must use the wide (4-byte) form
 }
 
 // Instruction represents a parsed instruction before encoding
 type Instruction struct {
-	Line      int       // 8 bytes (align first for best packing)
-	Immediate uint16    // 2 bytes
-	Opcode    Opcode    // 1 byte  
-	ExtOpcode ExtOpcode // 1 byte
-	Dst       Register  // 1 byte
-	Src       Register  // 1 byte
-	IsExt     bool      // 1 byte
-	IsImm     bool      // 1 byte
+	Line       int        // 8 bytes (align first for best packing)
+	Immediate  uint16     // 2 bytes
+	Opcode     Opcode     // 1 byte
+	ExtOpcode  ExtOpcode  // 1 byte
+	WideOpcode WideOpcode // 1 byte, valid when IsWide
+	Dst        Register   // 1 byte
+	Src        Register   // 1 byte
+	IsExt      bool       // 1 byte
+	IsImm      bool       // 1 byte
+	IsWide     bool       // 1 byte, set when this instruction was upgraded to the 4-byte wide form
 }
 
 // TODO: Extend this with more values in the future
@@ -1,15 +1,55 @@
 package main
 
-import "os"
+import "github.com/architmishra-15/vm/container"
 
-// Writer handles file output
+// Writer handles file output, assembling instructions and their symbol
+// table into a container.Image rather than a bare byte stream.
 type Writer struct{}
 
 func NewWriter() *Writer {
 	return &Writer{}
 }
 
-// WriteBinary writes bytecode to file
-func (w *Writer) WriteBinary(filename string, data []byte) error {
-	return os.WriteFile(filename, data, 0644)
+// WriteBinary writes code plus a symbol table and per-instruction source
+// line map to filename as a container file. symbolTable may be nil to
+// omit the symbol section.
+func (w *Writer) WriteBinary(filename string, instructions []Instruction, code []byte, symbolTable *SymbolTable) error {
+	writer := container.NewWriter()
+	return writer.WriteBinary(filename, code, symbolSection(symbolTable), lineMapSection(instructions), nil)
+}
+
+// symbolSection converts asm_parser's symbol table into the container
+// package's symbol rows, or nil if there's no table to serialize.
+func symbolSection(symbolTable *SymbolTable) []container.Symbol {
+	if symbolTable == nil {
+		return nil
+	}
+
+	symbols := symbolTable.AllSymbols()
+	out := make([]container.Symbol, 0, len(symbols))
+	for _, s := range symbols {
+		out = append(out, container.Symbol{
+			Name:    s.Name,
+			Address: s.Address,
+			Used:    s.Used,
+		})
+	}
+	return out
+}
+
+// lineMapSection builds an instruction-index -> source-line map so a
+// future debugger can step through source instead of raw words.
+func lineMapSection(instructions []Instruction) []container.LineMapEntry {
+	if instructions == nil {
+		return nil
+	}
+
+	out := make([]container.LineMapEntry, 0, len(instructions))
+	for i, instr := range instructions {
+		out = append(out, container.LineMapEntry{
+			InstructionIndex: uint32(i),
+			SourceLine:       uint32(instr.Line),
+		})
+	}
+	return out
 }
@@ -0,0 +1,77 @@
+// Package container implements the on-disk .cbin container format: a
+// fixed-size header describing what follows, plus optional sections for
+// symbols, a source-line map, and relocations. Writer produces a
+// container, Reader parses one back, so the assembler, the future vm
+// runtime, and disasm can all agree on one on-disk layout instead of
+// each hand-rolling their own.
+package container
+
+// Magic is the 4-byte signature every container file starts with -
+// unchanged from the original ad-hoc "cbin" prefix.
+const Magic = "cbin"
+
+// FormatVersion is the on-disk layout version. Bump it whenever the
+// header or section layout changes incompatibly.
+const FormatVersion uint16 = 1
+
+// Endianness flags for Header.Endian.
+const (
+	LittleEndian byte = 0
+	BigEndian    byte = 1
+)
+
+// HeaderSize is the fixed, on-disk size of the header, in bytes:
+// Magic(4) + Version(2) + Arch(2) + Endian(1) + reserved(3) +
+// EntryPoint(4) + CodeLength(4) + SymbolLength(4) + LineMapLength(4) +
+// RelocLength(4) + Checksum(4).
+const HeaderSize = 4 + 2 + 2 + 1 + 3 + 4*6
+
+// Header is the fixed-size preamble of a container file. A section
+// length of zero means that section is absent from the file entirely.
+type Header struct {
+	Magic         [4]byte
+	Version       uint16
+	Arch          uint16
+	Endian        byte
+	EntryPoint    uint32
+	CodeLength    uint32
+	SymbolLength  uint32
+	LineMapLength uint32
+	RelocLength   uint32
+	Checksum      uint32 // CRC32 of every section that follows the header
+}
+
+// Symbol is one entry of the optional symbol section, mirroring
+// asm_parser's Symbol type without depending on it.
+type Symbol struct {
+	Name    string
+	Address uint32
+	Used    bool
+}
+
+// LineMapEntry maps an instruction index to the source line it came
+// from, so a future debugger can step through source rather than raw
+// words.
+type LineMapEntry struct {
+	InstructionIndex uint32
+	SourceLine       uint32
+}
+
+// Relocation records that the instruction at Offset (a byte offset into
+// the code section) was produced by resolving Symbol. Nothing in
+// asm_parser emits these yet since it bakes resolved addresses directly
+// into instructions, but the section exists so a future linking step
+// doesn't need another format revision to add it.
+type Relocation struct {
+	Offset uint32
+	Symbol string
+}
+
+// Image is the fully decoded contents of a container file.
+type Image struct {
+	Header      Header
+	Code        []byte
+	Symbols     []Symbol
+	LineMap     []LineMapEntry
+	Relocations []Relocation
+}
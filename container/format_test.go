@@ -0,0 +1,85 @@
+package container
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestRoundTrip builds a container with every optional section populated
+// and checks that Reader.Parse reconstructs exactly what Writer.Build
+// produced.
+func TestRoundTrip(t *testing.T) {
+	w := &Writer{Arch: 1, EntryPoint: 4}
+	code := []byte{0x01, 0x02, 0x03, 0x04}
+	symbols := []Symbol{{Name: "main", Address: 0, Used: true}}
+	lineMap := []LineMapEntry{{InstructionIndex: 0, SourceLine: 1}}
+	relocs := []Relocation{{Offset: 0, Symbol: "main"}}
+
+	data := w.Build(code, symbols, lineMap, relocs)
+
+	image, err := NewReader().Parse(data)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	if !bytes.Equal(image.Code, code) {
+		t.Errorf("Code = %v, want %v", image.Code, code)
+	}
+	if len(image.Symbols) != 1 || image.Symbols[0] != symbols[0] {
+		t.Errorf("Symbols = %v, want %v", image.Symbols, symbols)
+	}
+	if len(image.LineMap) != 1 || image.LineMap[0] != lineMap[0] {
+		t.Errorf("LineMap = %v, want %v", image.LineMap, lineMap)
+	}
+	if len(image.Relocations) != 1 || image.Relocations[0] != relocs[0] {
+		t.Errorf("Relocations = %v, want %v", image.Relocations, relocs)
+	}
+	if image.Header.EntryPoint != 4 || image.Header.Arch != 1 {
+		t.Errorf("Header = %+v, want EntryPoint=4 Arch=1", image.Header)
+	}
+}
+
+// TestRoundTripNoSections checks that omitting all optional sections
+// round-trips to nil, not empty slices, preserving the "absent" vs
+// "empty" distinction encodeSymbols/encodeLineMap/encodeRelocations rely
+// on.
+func TestRoundTripNoSections(t *testing.T) {
+	w := NewWriter()
+	data := w.Build([]byte{0xAA, 0xBB}, nil, nil, nil)
+
+	image, err := NewReader().Parse(data)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if image.Symbols != nil || image.LineMap != nil || image.Relocations != nil {
+		t.Errorf("expected nil optional sections, got Symbols=%v LineMap=%v Relocations=%v",
+			image.Symbols, image.LineMap, image.Relocations)
+	}
+}
+
+// TestDecodeSymbolsTruncatedNameLen guards against the name-length
+// bounds check overflowing back to a small number for a corrupt, near
+// uint32-max nameLen - decodeSymbols must reject the entry instead of
+// slicing out of range.
+func TestDecodeSymbolsTruncatedNameLen(t *testing.T) {
+	buf := make([]byte, 8)
+	buf[0] = 1 // count = 1
+	// nameLen = 0xFFFFFFFC, so nameLen+4+1 overflows a uint32 back to 1.
+	buf[4], buf[5], buf[6], buf[7] = 0xFC, 0xFF, 0xFF, 0xFF
+
+	if _, err := decodeSymbols(buf); err == nil {
+		t.Fatal("expected an error for a corrupt nameLen, got nil")
+	}
+}
+
+// TestParseTruncatedFile checks that a header declaring more payload
+// than is actually present is rejected rather than panicking on a
+// short slice.
+func TestParseTruncatedFile(t *testing.T) {
+	w := NewWriter()
+	data := w.Build([]byte{0x01, 0x02}, nil, nil, nil)
+
+	if _, err := NewReader().Parse(data[:len(data)-1]); err == nil {
+		t.Fatal("expected an error for a truncated file, got nil")
+	}
+}
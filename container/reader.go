@@ -0,0 +1,82 @@
+package container
+
+import (
+	"fmt"
+	"hash/crc32"
+	"os"
+)
+
+// Reader parses a container file back into an Image.
+type Reader struct{}
+
+// NewReader creates a Reader.
+func NewReader() *Reader {
+	return &Reader{}
+}
+
+// ReadFile reads and parses a container file from disk.
+func (r *Reader) ReadFile(filename string) (*Image, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("container: reading %s: %w", filename, err)
+	}
+	return r.Parse(data)
+}
+
+// Parse decodes a container image already held in memory, verifying the
+// magic header and the payload checksum.
+func (r *Reader) Parse(data []byte) (*Image, error) {
+	if len(data) < HeaderSize {
+		return nil, fmt.Errorf("container: file too short for header (%d bytes, want %d)", len(data), HeaderSize)
+	}
+
+	header, err := decodeHeader(data[:HeaderSize])
+	if err != nil {
+		return nil, err
+	}
+
+	payload := data[HeaderSize:]
+	wantLen := int(header.CodeLength) + int(header.SymbolLength) + int(header.LineMapLength) + int(header.RelocLength)
+	if len(payload) < wantLen {
+		return nil, fmt.Errorf("container: truncated file: have %d payload bytes, header declares %d", len(payload), wantLen)
+	}
+	payload = payload[:wantLen]
+
+	if sum := crc32.ChecksumIEEE(payload); sum != header.Checksum {
+		return nil, fmt.Errorf("container: checksum mismatch (got 0x%08X, want 0x%08X)", sum, header.Checksum)
+	}
+
+	image := &Image{Header: header}
+
+	offset := 0
+	image.Code = payload[offset : offset+int(header.CodeLength)]
+	offset += int(header.CodeLength)
+
+	if header.SymbolLength > 0 {
+		symbols, err := decodeSymbols(payload[offset : offset+int(header.SymbolLength)])
+		if err != nil {
+			return nil, err
+		}
+		image.Symbols = symbols
+	}
+	offset += int(header.SymbolLength)
+
+	if header.LineMapLength > 0 {
+		lineMap, err := decodeLineMap(payload[offset : offset+int(header.LineMapLength)])
+		if err != nil {
+			return nil, err
+		}
+		image.LineMap = lineMap
+	}
+	offset += int(header.LineMapLength)
+
+	if header.RelocLength > 0 {
+		relocs, err := decodeRelocations(payload[offset : offset+int(header.RelocLength)])
+		if err != nil {
+			return nil, err
+		}
+		image.Relocations = relocs
+	}
+
+	return image, nil
+}
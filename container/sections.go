@@ -0,0 +1,208 @@
+package container
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Each optional section starts with a uint32 entry count, so decoding
+// never has to guess where one ends - Header already told us the whole
+// section's byte length for bounds-checking, and the count tells us how
+// many fixed/variable records to pull out of it.
+
+func encodeHeader(h Header) []byte {
+	buf := make([]byte, HeaderSize)
+	copy(buf[0:4], h.Magic[:])
+	binary.LittleEndian.PutUint16(buf[4:6], h.Version)
+	binary.LittleEndian.PutUint16(buf[6:8], h.Arch)
+	buf[8] = h.Endian
+	// buf[9:12] reserved
+	binary.LittleEndian.PutUint32(buf[12:16], h.EntryPoint)
+	binary.LittleEndian.PutUint32(buf[16:20], h.CodeLength)
+	binary.LittleEndian.PutUint32(buf[20:24], h.SymbolLength)
+	binary.LittleEndian.PutUint32(buf[24:28], h.LineMapLength)
+	binary.LittleEndian.PutUint32(buf[28:32], h.RelocLength)
+	binary.LittleEndian.PutUint32(buf[32:36], h.Checksum)
+	return buf
+}
+
+func decodeHeader(buf []byte) (Header, error) {
+	if len(buf) < HeaderSize {
+		return Header{}, fmt.Errorf("container: header too short (%d bytes, want %d)", len(buf), HeaderSize)
+	}
+
+	var h Header
+	copy(h.Magic[:], buf[0:4])
+	if string(h.Magic[:]) != Magic {
+		return Header{}, fmt.Errorf("container: missing %q magic header", Magic)
+	}
+
+	h.Version = binary.LittleEndian.Uint16(buf[4:6])
+	h.Arch = binary.LittleEndian.Uint16(buf[6:8])
+	h.Endian = buf[8]
+	h.EntryPoint = binary.LittleEndian.Uint32(buf[12:16])
+	h.CodeLength = binary.LittleEndian.Uint32(buf[16:20])
+	h.SymbolLength = binary.LittleEndian.Uint32(buf[20:24])
+	h.LineMapLength = binary.LittleEndian.Uint32(buf[24:28])
+	h.RelocLength = binary.LittleEndian.Uint32(buf[28:32])
+	h.Checksum = binary.LittleEndian.Uint32(buf[32:36])
+
+	if h.Version > FormatVersion {
+		return Header{}, fmt.Errorf("container: unsupported format version %d (know up to %d)", h.Version, FormatVersion)
+	}
+
+	return h, nil
+}
+
+// encodeSymbols returns nil (an absent section) when symbols is nil, and
+// a count-prefixed section otherwise, even if it's empty - that
+// distinction lets a reader tell "no symbol table was requested" apart
+// from "the symbol table was empty".
+func encodeSymbols(symbols []Symbol) []byte {
+	if symbols == nil {
+		return nil
+	}
+
+	buf := make([]byte, 4, 4+len(symbols)*9)
+	binary.LittleEndian.PutUint32(buf, uint32(len(symbols)))
+
+	for _, s := range symbols {
+		nameLen := make([]byte, 4)
+		binary.LittleEndian.PutUint32(nameLen, uint32(len(s.Name)))
+		buf = append(buf, nameLen...)
+		buf = append(buf, s.Name...)
+
+		addr := make([]byte, 4)
+		binary.LittleEndian.PutUint32(addr, s.Address)
+		buf = append(buf, addr...)
+
+		used := byte(0)
+		if s.Used {
+			used = 1
+		}
+		buf = append(buf, used)
+	}
+	return buf
+}
+
+func decodeSymbols(buf []byte) ([]Symbol, error) {
+	if len(buf) < 4 {
+		return nil, fmt.Errorf("container: symbol section too short")
+	}
+	count := binary.LittleEndian.Uint32(buf[:4])
+	buf = buf[4:]
+
+	symbols := make([]Symbol, 0, count)
+	for i := uint32(0); i < count; i++ {
+		if len(buf) < 4 {
+			return nil, fmt.Errorf("container: symbol section truncated reading entry %d", i)
+		}
+		nameLen := binary.LittleEndian.Uint32(buf[:4])
+		buf = buf[4:]
+
+		// Do the bounds check in uint64 so a corrupt nameLen near the
+		// uint32 max can't overflow nameLen+4+1 back down to a small
+		// number and sail past the truncation check below.
+		if uint64(len(buf)) < uint64(nameLen)+4+1 {
+			return nil, fmt.Errorf("container: symbol section truncated reading entry %d", i)
+		}
+		name := string(buf[:nameLen])
+		buf = buf[nameLen:]
+
+		addr := binary.LittleEndian.Uint32(buf[:4])
+		buf = buf[4:]
+
+		used := buf[0] != 0
+		buf = buf[1:]
+
+		symbols = append(symbols, Symbol{Name: name, Address: addr, Used: used})
+	}
+	return symbols, nil
+}
+
+func encodeLineMap(lineMap []LineMapEntry) []byte {
+	if lineMap == nil {
+		return nil
+	}
+
+	buf := make([]byte, 4, 4+len(lineMap)*8)
+	binary.LittleEndian.PutUint32(buf, uint32(len(lineMap)))
+
+	for _, e := range lineMap {
+		entry := make([]byte, 8)
+		binary.LittleEndian.PutUint32(entry[0:4], e.InstructionIndex)
+		binary.LittleEndian.PutUint32(entry[4:8], e.SourceLine)
+		buf = append(buf, entry...)
+	}
+	return buf
+}
+
+func decodeLineMap(buf []byte) ([]LineMapEntry, error) {
+	if len(buf) < 4 {
+		return nil, fmt.Errorf("container: line map section too short")
+	}
+	count := binary.LittleEndian.Uint32(buf[:4])
+	buf = buf[4:]
+
+	if uint32(len(buf)) < count*8 {
+		return nil, fmt.Errorf("container: line map section truncated")
+	}
+
+	entries := make([]LineMapEntry, 0, count)
+	for i := uint32(0); i < count; i++ {
+		entries = append(entries, LineMapEntry{
+			InstructionIndex: binary.LittleEndian.Uint32(buf[0:4]),
+			SourceLine:       binary.LittleEndian.Uint32(buf[4:8]),
+		})
+		buf = buf[8:]
+	}
+	return entries, nil
+}
+
+func encodeRelocations(relocs []Relocation) []byte {
+	if relocs == nil {
+		return nil
+	}
+
+	buf := make([]byte, 4, 4+len(relocs)*8)
+	binary.LittleEndian.PutUint32(buf, uint32(len(relocs)))
+
+	for _, r := range relocs {
+		offset := make([]byte, 4)
+		binary.LittleEndian.PutUint32(offset, r.Offset)
+		buf = append(buf, offset...)
+
+		nameLen := make([]byte, 4)
+		binary.LittleEndian.PutUint32(nameLen, uint32(len(r.Symbol)))
+		buf = append(buf, nameLen...)
+		buf = append(buf, r.Symbol...)
+	}
+	return buf
+}
+
+func decodeRelocations(buf []byte) ([]Relocation, error) {
+	if len(buf) < 4 {
+		return nil, fmt.Errorf("container: relocation section too short")
+	}
+	count := binary.LittleEndian.Uint32(buf[:4])
+	buf = buf[4:]
+
+	relocs := make([]Relocation, 0, count)
+	for i := uint32(0); i < count; i++ {
+		if len(buf) < 8 {
+			return nil, fmt.Errorf("container: relocation section truncated reading entry %d", i)
+		}
+		offset := binary.LittleEndian.Uint32(buf[:4])
+		nameLen := binary.LittleEndian.Uint32(buf[4:8])
+		buf = buf[8:]
+
+		if uint32(len(buf)) < nameLen {
+			return nil, fmt.Errorf("container: relocation section truncated reading entry %d", i)
+		}
+		name := string(buf[:nameLen])
+		buf = buf[nameLen:]
+
+		relocs = append(relocs, Relocation{Offset: offset, Symbol: name})
+	}
+	return relocs, nil
+}
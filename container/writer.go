@@ -0,0 +1,57 @@
+package container
+
+import (
+	"hash/crc32"
+	"os"
+)
+
+// Writer assembles a container file from a code blob plus whichever
+// optional sections the caller has available.
+type Writer struct {
+	Arch       uint16
+	EntryPoint uint32
+}
+
+// NewWriter creates a Writer for the default (0) architecture id and
+// entry point; set the fields directly to override either.
+func NewWriter() *Writer {
+	return &Writer{}
+}
+
+// WriteBinary builds a container and writes it to filename. symbols,
+// lineMap and relocs may all be nil to omit those sections.
+func (w *Writer) WriteBinary(filename string, code []byte, symbols []Symbol, lineMap []LineMapEntry, relocs []Relocation) error {
+	data := w.Build(code, symbols, lineMap, relocs)
+	return os.WriteFile(filename, data, 0644)
+}
+
+// Build serializes a full container to bytes without touching disk.
+func (w *Writer) Build(code []byte, symbols []Symbol, lineMap []LineMapEntry, relocs []Relocation) []byte {
+	symBytes := encodeSymbols(symbols)
+	lineBytes := encodeLineMap(lineMap)
+	relocBytes := encodeRelocations(relocs)
+
+	payload := make([]byte, 0, len(code)+len(symBytes)+len(lineBytes)+len(relocBytes))
+	payload = append(payload, code...)
+	payload = append(payload, symBytes...)
+	payload = append(payload, lineBytes...)
+	payload = append(payload, relocBytes...)
+
+	header := Header{
+		Version:       FormatVersion,
+		Arch:          w.Arch,
+		Endian:        LittleEndian,
+		EntryPoint:    w.EntryPoint,
+		CodeLength:    uint32(len(code)),
+		SymbolLength:  uint32(len(symBytes)),
+		LineMapLength: uint32(len(lineBytes)),
+		RelocLength:   uint32(len(relocBytes)),
+		Checksum:      crc32.ChecksumIEEE(payload),
+	}
+	copy(header.Magic[:], Magic)
+
+	out := make([]byte, 0, HeaderSize+len(payload))
+	out = append(out, encodeHeader(header)...)
+	out = append(out, payload...)
+	return out
+}
@@ -0,0 +1,191 @@
+package disasm
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Magic is the 4-byte header every .cbin file starts with.
+const Magic = "cbin"
+
+// Instruction is a single decoded instruction, ready to be formatted or
+// looked up by address.
+type Instruction struct {
+	Address  uint32
+	Raw      uint16
+	Raw2     uint16 // second word of a wide-format instruction; zero otherwise
+	IsWide   bool   // set for a 4-byte OP_WIDE instruction (see decodeWideWord)
+	Mnemonic string
+	Operands string
+}
+
+// Decoder reads a .cbin image and yields one Instruction per encoded
+// instruction: one 16-bit word, or two for a wide (OP_WIDE) instruction.
+type Decoder struct {
+	data []byte
+}
+
+// NewDecoder validates the magic header and returns a Decoder positioned
+// at the first instruction word. Use this for a bare, pre-container
+// ".cbin" blob (4-byte magic followed directly by instruction words).
+func NewDecoder(data []byte) (*Decoder, error) {
+	if len(data) < len(Magic) || string(data[:len(Magic)]) != Magic {
+		return nil, fmt.Errorf("disasm: missing %q magic header", Magic)
+	}
+	return &Decoder{data: data[len(Magic):]}, nil
+}
+
+// NewDecoderFromCode wraps an already-extracted code section, e.g.
+// container.Image.Code, skipping the magic-header check NewDecoder does
+// since the container package has already validated the file.
+func NewDecoderFromCode(code []byte) *Decoder {
+	return &Decoder{data: code}
+}
+
+// Decode walks the instruction stream and returns the decoded
+// instructions in file order. Most words are a single 16-bit
+// instruction, but an OP_WIDE word (see decodeWideWord) is the first
+// half of a 4-byte pair and consumes the word right after it too.
+func (d *Decoder) Decode() ([]Instruction, error) {
+	if len(d.data)%2 != 0 {
+		return nil, fmt.Errorf("disasm: truncated instruction stream (%d trailing byte(s))", len(d.data)%2)
+	}
+
+	instructions := make([]Instruction, 0, len(d.data)/2)
+	for off := 0; off < len(d.data); {
+		word := binary.LittleEndian.Uint16(d.data[off : off+2])
+
+		if Opcode(word>>12) == OP_WIDE {
+			if off+4 > len(d.data) {
+				return nil, fmt.Errorf("disasm: truncated wide instruction at address 0x%04X", off)
+			}
+			imm := binary.LittleEndian.Uint16(d.data[off+2 : off+4])
+			instr, err := decodeWideWord(uint32(off), word, imm)
+			if err != nil {
+				return nil, err
+			}
+			instructions = append(instructions, instr)
+			off += 4
+			continue
+		}
+
+		instr, err := decodeWord(uint32(off), word)
+		if err != nil {
+			return nil, err
+		}
+		instructions = append(instructions, instr)
+		off += 2
+	}
+	return instructions, nil
+}
+
+// decodeWord is the single dispatch function: it figures out which table
+// a word's opcode belongs to and formats it from there.
+func decodeWord(address uint32, word uint16) (Instruction, error) {
+	opcode := Opcode(word >> 12)
+
+	if opcode == OP_EXT {
+		return decodeExtended(address, word)
+	}
+
+	info, ok := opcodeTable[opcode]
+	if !ok {
+		return Instruction{}, fmt.Errorf("disasm: unknown opcode 0x%X at address 0x%04X", opcode, address)
+	}
+
+	dst := (word >> 9) & 0x7
+	src := (word >> 6) & 0x7
+	imm := word & 0x1FF
+
+	return Instruction{
+		Address:  address,
+		Raw:      word,
+		Mnemonic: info.Mnemonic,
+		Operands: formatOperands(info.Operands, dst, src, imm),
+	}, nil
+}
+
+func decodeExtended(address uint32, word uint16) (Instruction, error) {
+	extOp := ExtOpcode((word >> 9) & 0x7)
+	info, ok := extOpcodeTable[extOp]
+	if !ok {
+		return Instruction{}, fmt.Errorf("disasm: unknown extended opcode 0x%X at address 0x%04X", extOp, address)
+	}
+
+	dst := (word >> 6) & 0x7
+	src := (word >> 3) & 0x7
+
+	return Instruction{
+		Address:  address,
+		Raw:      word,
+		Mnemonic: info.Mnemonic,
+		Operands: formatOperands(info.Operands, dst, src, 0),
+	}, nil
+}
+
+// decodeWideWord decodes a 4-byte wide-format instruction: ctrl is the
+// first word (OP_WIDE(4) | WideOpcode(4) | Dst(4) | unused(4)) and imm is
+// the second word, the full 16-bit immediate - see
+// asm_parser.Encoder.encodeWide for the matching encode side.
+func decodeWideWord(address uint32, ctrl, imm uint16) (Instruction, error) {
+	wideOp := WideOpcode((ctrl >> 8) & 0xF)
+	info, ok := wideOpcodeTable[wideOp]
+	if !ok {
+		return Instruction{}, fmt.Errorf("disasm: unknown wide opcode 0x%X at address 0x%04X", wideOp, address)
+	}
+
+	dst := (ctrl >> 4) & 0xF
+
+	return Instruction{
+		Address:  address,
+		Raw:      ctrl,
+		Raw2:     imm,
+		IsWide:   true,
+		Mnemonic: info.Mnemonic,
+		Operands: formatWideOperands(info.Operands, dst, imm),
+	}, nil
+}
+
+// formatWideOperands mirrors formatOperands for the wide format, whose
+// fields don't share formatOperands' dst/src/imm packing (imm here is a
+// full word, not 9 bits shared with dst/src).
+func formatWideOperands(kind OperandType, dst, imm uint16) string {
+	switch kind {
+	case TypeRegImm:
+		return fmt.Sprintf("R%d, %d", dst, imm)
+	case TypeWideImm:
+		return fmt.Sprintf("%d", imm)
+	default:
+		return ""
+	}
+}
+
+// formatOperands is the one place every decoded instruction goes through
+// to turn its raw register/immediate fields into text, regardless of
+// which table it came from.
+func formatOperands(kind OperandType, dst, src, imm uint16) string {
+	switch kind {
+	case TypeNone:
+		return ""
+	case TypeOneReg:
+		return fmt.Sprintf("R%d", dst)
+	case TypeRegOrImm:
+		// asm_parser's Encoder packs these two shapes into the same
+		// bits (see encodeRegister/encodeImmediate): register form
+		// leaves the low 9 bits at zero, immediate form leaves the
+		// dst field at zero. A nonzero dst field means this word is
+		// register-addressed; otherwise treat the low 9 bits as the
+		// resolved address (both read as zero for R0/address-0,
+		// which is indistinguishable either way).
+		if dst == 0 && imm != 0 {
+			return fmt.Sprintf("%d", imm)
+		}
+		return fmt.Sprintf("R%d", dst)
+	case TypeTwoReg:
+		return fmt.Sprintf("R%d, R%d", dst, src)
+	case TypeRegImm:
+		return fmt.Sprintf("R%d, %d", dst, imm)
+	default:
+		return ""
+	}
+}
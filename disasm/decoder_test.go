@@ -0,0 +1,69 @@
+package disasm
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// TestDecodeWideWord checks the bit-unpacking for both wide-format
+// shapes: MOVI, which still carries a destination register, and JMP,
+// whose word is a plain 16-bit address with no register field.
+func TestDecodeWideWord(t *testing.T) {
+	// WIDE_MOVI R5, 0x1234
+	ctrl := uint16(OP_WIDE)<<12 | uint16(WIDE_MOVI)<<8 | 5<<4
+	instr, err := decodeWideWord(0, ctrl, 0x1234)
+	if err != nil {
+		t.Fatalf("decodeWideWord(MOVI): %v", err)
+	}
+	if instr.Mnemonic != "MOVI" || instr.Operands != "R5, 4660" || !instr.IsWide {
+		t.Errorf("MOVI decode = %+v, want Mnemonic=MOVI Operands=\"R5, 4660\" IsWide=true", instr)
+	}
+	if instr.Raw != ctrl || instr.Raw2 != 0x1234 {
+		t.Errorf("MOVI Raw/Raw2 = %04X/%04X, want %04X/1234", instr.Raw, instr.Raw2, ctrl)
+	}
+
+	// WIDE_JMP 0x2000
+	ctrl = uint16(OP_WIDE)<<12 | uint16(WIDE_JMP)<<8
+	instr, err = decodeWideWord(0, ctrl, 0x2000)
+	if err != nil {
+		t.Fatalf("decodeWideWord(JMP): %v", err)
+	}
+	if instr.Mnemonic != "JMP" || instr.Operands != "8192" {
+		t.Errorf("JMP decode = %+v, want Mnemonic=JMP Operands=8192", instr)
+	}
+}
+
+// TestDecodeWideWordUnknownOpcode checks that an unrecognized wide
+// opcode nibble is reported rather than silently decoded as zero values.
+func TestDecodeWideWordUnknownOpcode(t *testing.T) {
+	ctrl := uint16(OP_WIDE)<<12 | 0xF<<8 // 0xF is not in wideOpcodeTable
+	if _, err := decodeWideWord(0, ctrl, 0); err == nil {
+		t.Fatal("expected an error for an unknown wide opcode, got nil")
+	}
+}
+
+// TestDecodeWideInstructionStream checks that Decoder.Decode consumes
+// a wide instruction's full 4 bytes and resumes correctly at the next
+// narrow instruction, rather than desyncing by treating Raw2 as its own
+// word.
+func TestDecodeWideInstructionStream(t *testing.T) {
+	data := make([]byte, 6)
+	ctrl := uint16(OP_WIDE)<<12 | uint16(WIDE_MOVI)<<8 | 2<<4
+	binary.LittleEndian.PutUint16(data[0:2], ctrl)
+	binary.LittleEndian.PutUint16(data[2:4], 0x00FF)
+	binary.LittleEndian.PutUint16(data[4:6], uint16(OP_HALT)<<12)
+
+	instructions, err := NewDecoderFromCode(data).Decode()
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if len(instructions) != 2 {
+		t.Fatalf("got %d instructions, want 2", len(instructions))
+	}
+	if !instructions[0].IsWide || instructions[0].Address != 0 {
+		t.Errorf("instructions[0] = %+v, want IsWide=true Address=0", instructions[0])
+	}
+	if instructions[1].IsWide || instructions[1].Address != 4 || instructions[1].Mnemonic != "HALT" {
+		t.Errorf("instructions[1] = %+v, want IsWide=false Address=4 Mnemonic=HALT", instructions[1])
+	}
+}
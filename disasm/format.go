@@ -0,0 +1,28 @@
+package disasm
+
+import (
+	"fmt"
+	"io"
+)
+
+// Dump writes one line per instruction to w: address, raw hex word,
+// mnemonic, and operands, e.g.:
+//
+//	0x0004  3404  MOVI  R2, 4
+func Dump(w io.Writer, instructions []Instruction) error {
+	for _, instr := range instructions {
+		var line string
+		if instr.IsWide {
+			line = fmt.Sprintf("0x%04X  %04X%04X  %s", instr.Address, instr.Raw, instr.Raw2, instr.Mnemonic)
+		} else {
+			line = fmt.Sprintf("0x%04X  %04X  %s", instr.Address, instr.Raw, instr.Mnemonic)
+		}
+		if instr.Operands != "" {
+			line += "  " + instr.Operands
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
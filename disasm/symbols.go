@@ -0,0 +1,93 @@
+package disasm
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// SymbolMap resolves addresses to label names for re-annotating jump and
+// call operands during disassembly.
+type SymbolMap map[uint32]string
+
+// LoadSymbolMap reads a sidecar symbol file: one "<address> <name>" pair
+// per line, address as a decimal or 0x-prefixed hex literal. Blank lines
+// and lines starting with ';' are ignored.
+func LoadSymbolMap(path string) (SymbolMap, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("disasm: opening symbol map: %w", err)
+	}
+	defer f.Close()
+
+	symbols := make(SymbolMap)
+
+	scanner := bufio.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("disasm: symbol map line %d: expected \"<address> <name>\", got %q", lineNo, line)
+		}
+
+		addr, err := strconv.ParseUint(strings.TrimPrefix(strings.ToLower(fields[0]), "0x"), hexOrDec(fields[0]), 32)
+		if err != nil {
+			return nil, fmt.Errorf("disasm: symbol map line %d: %w", lineNo, err)
+		}
+
+		symbols[uint32(addr)] = fields[1]
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("disasm: reading symbol map: %w", err)
+	}
+
+	return symbols, nil
+}
+
+func hexOrDec(field string) int {
+	if strings.HasPrefix(strings.ToLower(field), "0x") {
+		return 16
+	}
+	return 10
+}
+
+// Annotate rewrites the operand text of jump/call-shaped instructions
+// (single-operand, immediate-style addresses) so the raw address is
+// replaced with the label name, when one is known.
+func (sm SymbolMap) Annotate(instructions []Instruction) {
+	for i, instr := range instructions {
+		switch instr.Mnemonic {
+		case "JMP", "JZ", "JNZ", "CALL":
+			addr, ok := parseImmediateOperand(instr.Operands)
+			if !ok {
+				continue
+			}
+			if name, ok := sm[addr]; ok {
+				instructions[i].Operands = name
+			}
+		}
+	}
+}
+
+// parseImmediateOperand recognizes the plain-number operand form
+// formatOperands emits for an address-shaped jump/call target (see
+// TypeRegOrImm). A register-shaped target prints as "Rn" and is left
+// alone, since there's no fixed address to annotate.
+func parseImmediateOperand(operand string) (uint32, bool) {
+	if strings.HasPrefix(operand, "R") {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(operand, 10, 32)
+	if err != nil {
+		return 0, false
+	}
+	return uint32(n), true
+}
@@ -0,0 +1,122 @@
+// Package disasm decodes cbin bytecode back into human-readable assembly.
+//
+// It deliberately does not import asm_parser: the two directions of the
+// pipeline are expected to drift as the ISA grows (see x/arch's armasm,
+// which keeps its instFormat mask/value tables separate from the
+// assembler for the same reason), so the opcode tables below are a
+// decode-side mirror of asm_parser/opcodes.go rather than a shared import.
+package disasm
+
+// Opcode is a decoded top-level opcode.
+type Opcode uint8
+
+const (
+	OP_HALT   Opcode = 0x0
+	OP_NOP    Opcode = 0x1
+	OP_MOV    Opcode = 0x2
+	OP_MOVI   Opcode = 0x3
+	OP_CMP    Opcode = 0x4
+	OP_JMP    Opcode = 0x5
+	OP_JZ     Opcode = 0x6
+	OP_JNZ    Opcode = 0x7
+	OP_PUSH   Opcode = 0x8
+	OP_POP    Opcode = 0x9
+	OP_CALL   Opcode = 0xA
+	OP_STDOUT Opcode = 0xB
+	OP_STDIN  Opcode = 0xC
+	OP_EXT    Opcode = 0xD
+	OP_WIDE   Opcode = 0xE
+)
+
+// ExtOpcode is a decoded extended opcode (valid when Opcode == OP_EXT).
+type ExtOpcode uint8
+
+const (
+	EXT_RET   ExtOpcode = 0x0
+	EXT_LOAD  ExtOpcode = 0x1
+	EXT_STORE ExtOpcode = 0x2
+	EXT_ADD   ExtOpcode = 0x3
+	EXT_SUB   ExtOpcode = 0x4
+	EXT_AND   ExtOpcode = 0x5
+	EXT_OR    ExtOpcode = 0x6
+	EXT_XOR   ExtOpcode = 0x7
+)
+
+// WideOpcode is a decoded wide-format opcode (valid when Opcode ==
+// OP_WIDE), mirroring asm_parser.WideOpcode.
+type WideOpcode uint8
+
+const (
+	WIDE_MOVI WideOpcode = 0x0
+	WIDE_JMP  WideOpcode = 0x1
+	WIDE_JZ   WideOpcode = 0x2
+	WIDE_JNZ  WideOpcode = 0x3
+	WIDE_CALL WideOpcode = 0x4
+)
+
+// OperandType describes the operand shape of a decoded instruction so a
+// single dispatch function (formatOperands) can format any of them.
+type OperandType int
+
+const (
+	TypeNone     OperandType = iota // No operands (HALT, NOP)
+	TypeOneReg                      // One register (PUSH, POP, etc.)
+	TypeRegOrImm                    // Register or resolved address (JMP, CALL, etc.)
+	TypeTwoReg                      // Two registers (MOV, ADD, SUB, etc.)
+	TypeRegImm                      // Register + immediate (MOVI)
+	TypeExtended                    // Extended opcode instruction
+	TypeWideImm                     // Full 16-bit address, no register (wide JMP/JZ/JNZ/CALL)
+)
+
+// instFormat is one row of a mask/value style decode table: given an
+// opcode, it says what the instruction is called and how to read its
+// operand bits.
+type instFormat struct {
+	Mnemonic string
+	Operands OperandType
+}
+
+// opcodeTable mirrors asm_parser.OpcodeTable.
+var opcodeTable = map[Opcode]instFormat{
+	OP_HALT:   {"HALT", TypeNone},
+	OP_NOP:    {"NOP", TypeNone},
+	OP_MOV:    {"MOV", TypeTwoReg},
+	OP_MOVI:   {"MOVI", TypeRegImm},
+	OP_CMP:    {"CMP", TypeTwoReg},
+	OP_JMP:    {"JMP", TypeRegOrImm},
+	OP_JZ:     {"JZ", TypeRegOrImm},
+	OP_JNZ:    {"JNZ", TypeRegOrImm},
+	OP_PUSH:   {"PUSH", TypeOneReg},
+	OP_POP:    {"POP", TypeOneReg},
+	OP_CALL:   {"CALL", TypeRegOrImm},
+	OP_STDOUT: {"STDOUT", TypeOneReg},
+	OP_STDIN:  {"STDIN", TypeOneReg},
+	OP_EXT:    {"EXT", TypeExtended},
+	OP_WIDE:   {"WIDE", TypeExtended},
+}
+
+// extOpcodeTable mirrors asm_parser.ExtOpcodeTable.
+var extOpcodeTable = map[ExtOpcode]instFormat{
+	EXT_RET:   {"RET", TypeNone},
+	EXT_LOAD:  {"LOAD", TypeTwoReg},
+	EXT_STORE: {"STORE", TypeTwoReg},
+	EXT_ADD:   {"ADD", TypeTwoReg},
+	EXT_SUB:   {"SUB", TypeTwoReg},
+	EXT_AND:   {"AND", TypeTwoReg},
+	EXT_OR:    {"OR", TypeTwoReg},
+	EXT_XOR:   {"XOR", TypeTwoReg},
+}
+
+// wideOpcodeTable mirrors asm_parser.WideOpcodeMap's reverse: the
+// mnemonic and operand shape for each wide-format opcode. Wide MOVI
+// still carries a destination register; the jump/call family never
+// upgrades a register-addressed form (see asm_parser.Parser.ParseRegular,
+// which only widens immediate forms),
+// so their operand is always the plain resolved address.
+var wideOpcodeTable = map[WideOpcode]instFormat{
+	WIDE_MOVI: {"MOVI", TypeRegImm},
+	WIDE_JMP:  {"JMP", TypeWideImm},
+	WIDE_JZ:   {"JZ", TypeWideImm},
+	WIDE_JNZ:  {"JNZ", TypeWideImm},
+	WIDE_CALL: {"CALL", TypeWideImm},
+}
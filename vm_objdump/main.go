@@ -0,0 +1,53 @@
+// Command vm-objdump disassembles .cbin container files produced by
+// asm_parser back into human-readable assembly, in the spirit of
+// objdump -d.
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/architmishra-15/vm/container"
+	"github.com/architmishra-15/vm/disasm"
+)
+
+func main() {
+	if len(os.Args) < 2 || len(os.Args) > 3 {
+		fmt.Fprintf(os.Stderr, "Usage: %s <input.cbin> [symbols.map]\n", os.Args[0])
+		os.Exit(1)
+	}
+
+	image, err := container.NewReader().ReadFile(os.Args[1])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	instructions, err := disasm.NewDecoderFromCode(image.Code).Decode()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	symbols := make(disasm.SymbolMap)
+	for _, s := range image.Symbols {
+		symbols[s.Address] = s.Name
+	}
+
+	if len(os.Args) == 3 {
+		sidecar, err := disasm.LoadSymbolMap(os.Args[2])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		for addr, name := range sidecar {
+			symbols[addr] = name
+		}
+	}
+	symbols.Annotate(instructions)
+
+	if err := disasm.Dump(os.Stdout, instructions); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+		os.Exit(1)
+	}
+}